@@ -0,0 +1,65 @@
+// Command ec2pricing prints EC2 price quotes from the command line.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/davent/ec2pricing/pkg/ec2pricing"
+)
+
+const DEFAULT_REGION string = "us-west-2"
+
+func main() {
+
+	// Command line arguments
+	instance_types := flag.String("types", "m4.4xlarge", "Comma-separated EC2 Instance types")
+	tenancy := flag.String("tenancy", "Shared", "EC2 Tenancy type")
+	operating_system := flag.String("os", "Linux", "EC2 Operating system")
+	term := flag.String("term", "OnDemand", "EC2 Term (OnDemand, Reserved, Spot or SavingsPlan)")
+	regions := flag.String("regions", DEFAULT_REGION, "Comma-separated EC2 Regions")
+	lease_length := flag.String("lease-length", "1yr", "Reserved Instance lease contract length (1yr or 3yr)")
+	purchase_option := flag.String("purchase-option", "No Upfront", "Reserved Instance purchase option")
+	offering_class := flag.String("offering-class", "standard", "Reserved Instance offering class (standard or convertible)")
+	spot_window := flag.Duration("spot-window", time.Hour, "Window to average Spot price history over")
+
+	flag.Parse()
+
+	client, err := ec2pricing.NewClient()
+	if err != nil {
+		log.Fatalf("Could not create ec2pricing client: %s", err)
+	}
+
+	q := ec2pricing.BatchQuery{
+		Regions:         strings.Split(*regions, ","),
+		InstanceTypes:   strings.Split(*instance_types, ","),
+		Tenancy:         *tenancy,
+		OperatingSystem: *operating_system,
+		Term:            *term,
+		TermOptions: ec2pricing.TermOptions{
+			LeaseContractLength: *lease_length,
+			PurchaseOption:      *purchase_option,
+			OfferingClass:       *offering_class,
+		},
+		SpotWindow: *spot_window,
+	}
+
+	// Get the price quotes for every region/instance type combination
+	prices, err := client.Prices(context.Background(), q)
+	if err != nil {
+		log.Fatalf("Could not get EC2 Prices: %s", err)
+	}
+
+	// Return the prices to STDOUT as JSON
+	out, err := json.MarshalIndent(prices, "", "  ")
+	if err != nil {
+		log.Fatalf("Could not encode prices: %s", err)
+	}
+	fmt.Println(string(out))
+
+}
@@ -0,0 +1,197 @@
+package ec2pricing
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bluele/gcache"
+	"github.com/go-redis/redis/v8"
+)
+
+// QUOTE_CACHE_TTL and SKU_CACHE_TTL are how long a PriceQuote and a
+// resolved product SKU stay valid, respectively. SKUs change far less
+// often than prices, so they're kept around much longer.
+const QUOTE_CACHE_TTL time.Duration = 24 * time.Hour
+const SKU_CACHE_TTL time.Duration = 7 * 24 * time.Hour
+
+// REFRESH_THRESHOLD is how close to expiry, as a fraction of its TTL,
+// a cache entry can get before it's served as-is while a fresh value
+// is fetched in the background.
+const REFRESH_THRESHOLD float64 = 0.1
+
+// nearExpiry reports whether remaining is within REFRESH_THRESHOLD of ttl.
+func nearExpiry(remaining time.Duration, ttl time.Duration) bool {
+	return remaining < time.Duration(float64(ttl)*REFRESH_THRESHOLD)
+}
+
+// PriceKey uniquely identifies a cached price quote.
+type PriceKey struct {
+	Region         string
+	InstanceType   string
+	Tenancy        string
+	OS             string
+	Term           string
+	PurchaseOption string
+	LeaseLength    string
+	OfferingClass  string
+	SpotWindow     time.Duration // only meaningful when Term is "Spot"
+}
+
+// String renders k as a stable cache key.
+func (k PriceKey) String() string {
+	return "quote:" + GetMD5Hash(fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%s|%s",
+		k.Region, k.InstanceType, k.Tenancy, k.OS, k.Term, k.PurchaseOption, k.LeaseLength, k.OfferingClass, k.SpotWindow))
+}
+
+// skuKey is the cache key for a resolved SKU, which only depends on
+// product attributes and not on term/lease options.
+func skuKey(region string, instance_type string, tenancy string, operating_system string) string {
+	return "sku:" + GetMD5Hash(region+instance_type+tenancy+operating_system)
+}
+
+// Cache stores byte values under a key for a TTL, with pluggable
+// backends (filesystem, in-memory LRU, Redis).
+type Cache interface {
+	// Get returns the cached value for key and how long remains
+	// before it expires, or ok=false if there's no live entry.
+	Get(ctx context.Context, key string) (value []byte, remaining time.Duration, ok bool)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+}
+
+// fileCacheEntry is what FileCache persists to disk per key.
+type fileCacheEntry struct {
+	Value     []byte
+	ExpiresAt time.Time
+}
+
+// FileCache is a Cache backed by gob-encoded files on disk.
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache returns a FileCache rooted at dir, creating it if
+// necessary.
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	return &FileCache{dir: dir}, nil
+}
+
+func (c *FileCache) path(key string) string {
+	return filepath.Join(c.dir, GetMD5Hash(key))
+}
+
+func (c *FileCache) Get(ctx context.Context, key string) ([]byte, time.Duration, bool) {
+	body, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, 0, false
+	}
+
+	entry := fileCacheEntry{}
+	if err := gob.NewDecoder(bytes.NewReader(body)).Decode(&entry); err != nil {
+		return nil, 0, false
+	}
+
+	remaining := time.Until(entry.ExpiresAt)
+	if remaining <= 0 {
+		return nil, 0, false
+	}
+
+	return entry.Value, remaining, true
+}
+
+func (c *FileCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	entry := fileCacheEntry{Value: value, ExpiresAt: time.Now().Add(ttl)}
+
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(entry); err != nil {
+		log.Printf("Could not encode cache entry for %s: %s", key, err)
+		return
+	}
+
+	if err := ioutil.WriteFile(c.path(key), buf.Bytes(), 0777); err != nil {
+		log.Printf("Could not write cache entry for %s: %s", key, err)
+	}
+}
+
+// memoryCacheEntry is what MemoryCache keeps per key in the LRU.
+type memoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryCache is a Cache backed by an in-memory LRU.
+type MemoryCache struct {
+	gc gcache.Cache
+}
+
+// NewMemoryCache returns a MemoryCache holding at most size entries.
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{gc: gcache.New(size).LRU().Build()}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, time.Duration, bool) {
+	v, err := c.gc.Get(key)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	entry, ok := v.(memoryCacheEntry)
+	if !ok {
+		return nil, 0, false
+	}
+
+	remaining := time.Until(entry.expiresAt)
+	if remaining <= 0 {
+		return nil, 0, false
+	}
+
+	return entry.value, remaining, true
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	entry := memoryCacheEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	if err := c.gc.SetWithExpire(key, entry, ttl); err != nil {
+		log.Printf("Could not write cache entry for %s: %s", key, err)
+	}
+}
+
+// RedisCache is a Cache backed by Redis, for sharing quotes across
+// multiple instances of the tool.
+type RedisCache struct {
+	rdb *redis.Client
+}
+
+// NewRedisCache wraps an existing Redis client.
+func NewRedisCache(rdb *redis.Client) *RedisCache {
+	return &RedisCache{rdb: rdb}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, time.Duration, bool) {
+	value, err := c.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, 0, false
+	}
+
+	ttl, err := c.rdb.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		return nil, 0, false
+	}
+
+	return value, ttl, true
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if err := c.rdb.Set(ctx, key, value, ttl).Err(); err != nil {
+		log.Printf("Could not write cache entry for %s: %s", key, err)
+	}
+}
@@ -0,0 +1,53 @@
+package ec2pricing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNearExpiry(t *testing.T) {
+	ttl := 10 * time.Minute
+
+	cases := []struct {
+		name      string
+		remaining time.Duration
+		want      bool
+	}{
+		{"fresh", 9 * time.Minute, false},
+		{"just outside threshold", 61 * time.Second, false},
+		{"within threshold", 30 * time.Second, true},
+		{"expired", -time.Second, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := nearExpiry(tc.remaining, ttl); got != tc.want {
+				t.Errorf("nearExpiry(%s, %s) = %v, want %v", tc.remaining, ttl, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPriceKeyString(t *testing.T) {
+	base := PriceKey{
+		Region:       "us-west-2",
+		InstanceType: "m4.4xlarge",
+		Tenancy:      "Shared",
+		OS:           "Linux",
+		Term:         "Spot",
+	}
+
+	oneHour := base
+	oneHour.SpotWindow = time.Hour
+
+	oneDay := base
+	oneDay.SpotWindow = 24 * time.Hour
+
+	if oneHour.String() == oneDay.String() {
+		t.Error("PriceKey.String() collided for different SpotWindow values")
+	}
+
+	if oneHour.String() != oneHour.String() {
+		t.Error("PriceKey.String() is not stable across calls")
+	}
+}
@@ -0,0 +1,795 @@
+// Package ec2pricing looks up EC2 OnDemand, Reserved, Spot and Savings
+// Plan prices via the AWS Pricing, EC2 and Savings Plans APIs.
+package ec2pricing
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/savingsplans"
+)
+
+// PRICING_API_REGION is the only AWS region (besides ap-south-1) that
+// serves the Price List API and the Savings Plans API, regardless of
+// which region's prices are being looked up.
+const PRICING_API_REGION string = "us-east-1"
+
+// MAX_CONCURRENT_REQUESTS bounds how many Pricing/EC2/Savings Plans
+// lookups a single BatchQuery will have in flight at once.
+const MAX_CONCURRENT_REQUESTS int = 10
+
+// regionLocations maps EC2 region codes to the "location" attribute
+// used by the Pricing API, across the commercial, GovCloud and China
+// partitions.
+var regionLocations = map[string]string{
+	"us-east-1":      "US East (N. Virginia)",
+	"us-east-2":      "US East (Ohio)",
+	"us-west-1":      "US West (N. California)",
+	"us-west-2":      "US West (Oregon)",
+	"af-south-1":     "Africa (Cape Town)",
+	"ap-east-1":      "Asia Pacific (Hong Kong)",
+	"ap-south-1":     "Asia Pacific (Mumbai)",
+	"ap-northeast-1": "Asia Pacific (Tokyo)",
+	"ap-northeast-2": "Asia Pacific (Seoul)",
+	"ap-northeast-3": "Asia Pacific (Osaka)",
+	"ap-southeast-1": "Asia Pacific (Singapore)",
+	"ap-southeast-2": "Asia Pacific (Sydney)",
+	"ca-central-1":   "Canada (Central)",
+	"eu-central-1":   "EU (Frankfurt)",
+	"eu-west-1":      "EU (Ireland)",
+	"eu-west-2":      "EU (London)",
+	"eu-west-3":      "EU (Paris)",
+	"eu-north-1":     "EU (Stockholm)",
+	"eu-south-1":     "EU (Milan)",
+	"me-south-1":     "Middle East (Bahrain)",
+	"sa-east-1":      "South America (Sao Paulo)",
+	"us-gov-east-1":  "AWS GovCloud (US-East)",
+	"us-gov-west-1":  "AWS GovCloud (US-West)",
+	"cn-north-1":     "China (Beijing)",
+	"cn-northwest-1": "China (Ningxia)",
+}
+
+// reservedTermHours gives the number of hours in a Reserved Instance
+// lease term, used to amortize its upfront cost.
+var reservedTermHours = map[string]float64{
+	"1yr": 365 * 24,
+	"3yr": 3 * 365 * 24,
+}
+
+// spotProductDescriptions maps the operatingSystem values used
+// elsewhere in this package to the ProductDescription values accepted
+// by ec2.DescribeSpotPriceHistory.
+var spotProductDescriptions = map[string]string{
+	"Linux":   "Linux/UNIX",
+	"RHEL":    "Red Hat Enterprise Linux",
+	"SUSE":    "SUSE Linux",
+	"Windows": "Windows",
+}
+
+type Product struct {
+	SKU           string            `json:"sku"`           // The SKU of the product
+	ProductFamily string            `json:"productFamily"` // The product family of the product
+	Attributes    map[string]string `json:"attributes"`
+}
+
+type Term struct {
+	OfferTermCode      string            `json:"offerTermCode"`      // The term code of the product
+	SKU                string            `json:"sku"`                // The SKU of the product
+	EffectiveDate      string            `json:"effectiveDate"`      // The effective date of the pricing details
+	TermAttributesType string            `json:"termAttributesType"` // The attribute type of the terms
+	TermAttributes     map[string]string `json:"termAttributes"`
+
+	PriceDimensions map[string]PriceDimension `json:"priceDimensions"`
+}
+
+type PriceDimension struct {
+	Description   string            `json:"description"`   // The description of the term
+	Unit          string            `json:"unit"`          // The usage measurement unit for the price
+	StartingRange string            `json:"startingRange"` // The start range for the term
+	EndingRange   string            `json:"endingRange"`   // The end range for the term
+	PricePerUnit  map[string]string `json:"pricePerUnit"`  // The rate code of the price
+}
+
+type Price struct {
+	Currency string
+	Value    string
+}
+
+// TermOptions carries the attributes that select a specific Reserved
+// Instance offering.
+type TermOptions struct {
+	LeaseContractLength string // "1yr" or "3yr"
+	PurchaseOption      string // "No Upfront", "Partial Upfront" or "All Upfront"
+	OfferingClass       string // "standard" or "convertible"
+}
+
+// PriceQuote is the result of a price lookup, regardless of which term
+// ("OnDemand", "Reserved", "Spot" or "SavingsPlan") produced it. For
+// Reserved quotes, EffectiveHourly amortizes UpfrontCost over the
+// lease term so that quotes across terms are directly comparable.
+type PriceQuote struct {
+	Term            string
+	TermOptions     *TermOptions // set only when Term is "Reserved"
+	HourlyCost      float64
+	UpfrontCost     float64
+	EffectiveHourly float64
+	SpotPrices      map[string]float64 // per-AZ average Spot price; set only when Term is "Spot"
+}
+
+// Query describes a single price lookup.
+type Query struct {
+	Region          string
+	InstanceType    string
+	Tenancy         string
+	OperatingSystem string
+	Term            string
+	TermOptions     TermOptions
+	SpotWindow      time.Duration
+}
+
+// BatchQuery describes a price lookup across every combination of
+// Regions and InstanceTypes. Tenancy, OperatingSystem, Term,
+// TermOptions and SpotWindow apply to the whole batch.
+type BatchQuery struct {
+	Regions         []string
+	InstanceTypes   []string
+	Tenancy         string
+	OperatingSystem string
+	Term            string
+	TermOptions     TermOptions
+	SpotWindow      time.Duration
+}
+
+// Filter selects the products returned by Client.Products. Region is
+// required; InstanceType, Tenancy and OperatingSystem narrow the
+// results further when set.
+type Filter struct {
+	Region          string
+	InstanceType    string
+	Tenancy         string
+	OperatingSystem string
+}
+
+// priceJob is one region/instance type pair to be priced.
+type priceJob struct {
+	region        string
+	instance_type string
+}
+
+// priceResult is the outcome of pricing a single priceJob.
+type priceResult struct {
+	region        string
+	instance_type string
+	quote         *PriceQuote
+	err           error
+}
+
+// priceListEntry mirrors the shape of a single entry returned in
+// GetProductsOutput.PriceList: a product plus its pricing terms.
+type priceListEntry struct {
+	Product Product                               `json:"product"`
+	Terms   map[string]map[string]map[string]Term `json:"terms"`
+}
+
+// Client wraps the AWS Pricing, EC2 and Savings Plans APIs so that
+// lookups can be done with server-side filters instead of downloading
+// the entire offer index.
+type Client struct {
+	sess          *session.Session
+	pricingRegion string
+	pricingSvc    *pricing.Pricing
+	cache         Cache
+	logger        *log.Logger
+}
+
+// Option configures a Client built by NewClient.
+type Option func(*Client)
+
+// WithSession uses sess for every AWS call instead of the default
+// session, letting callers bring their own credential chain (assume
+// role, IMDS, etc).
+func WithSession(sess *session.Session) Option {
+	return func(c *Client) {
+		c.sess = sess
+	}
+}
+
+// WithCache stores resolved SKUs and price quotes in cache instead of
+// the default filesystem cache.
+func WithCache(cache Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// WithPricingRegion overrides which region the Pricing and Savings
+// Plans APIs are queried in. They're only available in us-east-1 and
+// ap-south-1.
+func WithPricingRegion(region string) Option {
+	return func(c *Client) {
+		c.pricingRegion = region
+	}
+}
+
+// WithLogger sends the Client's diagnostic logging (failed background
+// refreshes) to logger instead of the default stderr logger.
+func WithLogger(logger *log.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// NewClient builds a Client, applying opts in order. By default it
+// uses the standard AWS session, a filesystem cache under
+// /tmp/.aws_pricing, and a logger writing to stderr.
+func NewClient(opts ...Option) (*Client, error) {
+
+	c := &Client{
+		pricingRegion: PRICING_API_REGION,
+		logger:        log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("could not create AWS session: %s", err)
+	}
+	c.sess = sess
+
+	cache, err := NewFileCache("/tmp/.aws_pricing")
+	if err != nil {
+		return nil, fmt.Errorf("could not create cache: %s", err)
+	}
+	c.cache = cache
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.pricingSvc = pricing.New(c.sess, aws.NewConfig().WithRegion(c.pricingRegion))
+
+	return c, nil
+}
+
+// Price returns a PriceQuote for q.InstanceType in q.Region under
+// q.Term. q.TermOptions only applies when q.Term is "Reserved";
+// q.SpotWindow only applies when q.Term is "Spot". Quotes are cached
+// for QUOTE_CACHE_TTL; once a cached quote is within
+// REFRESH_THRESHOLD of expiry it's returned immediately while a fresh
+// one is fetched in the background, so callers with a cached quote
+// don't block on the AWS API. On a cache miss the quote is fetched
+// before Price returns.
+func (c *Client) Price(ctx context.Context, q Query) (*PriceQuote, error) {
+
+	key := PriceKey{
+		Region:         q.Region,
+		InstanceType:   q.InstanceType,
+		Tenancy:        q.Tenancy,
+		OS:             q.OperatingSystem,
+		Term:           q.Term,
+		PurchaseOption: q.TermOptions.PurchaseOption,
+		LeaseLength:    q.TermOptions.LeaseContractLength,
+		OfferingClass:  q.TermOptions.OfferingClass,
+		SpotWindow:     q.SpotWindow,
+	}
+
+	if body, remaining, ok := c.cache.Get(ctx, key.String()); ok {
+		quote := &PriceQuote{}
+		if err := gob.NewDecoder(bytes.NewReader(body)).Decode(quote); err == nil {
+			if nearExpiry(remaining, QUOTE_CACHE_TTL) {
+				go func() {
+					bgCtx := context.Background()
+					if _, err := c.fetchPrice(bgCtx, key, q); err != nil {
+						c.logger.Printf("Background refresh failed for %+v: %s", key, err)
+					}
+				}()
+			}
+			return quote, nil
+		}
+	}
+
+	return c.fetchPrice(ctx, key, q)
+}
+
+// fetchPrice fetches a fresh PriceQuote from AWS and stores it under
+// key for QUOTE_CACHE_TTL.
+func (c *Client) fetchPrice(ctx context.Context, key PriceKey, q Query) (*PriceQuote, error) {
+
+	var quote *PriceQuote
+	var err error
+	switch q.Term {
+	case "Spot":
+		quote, err = c.getSpotPrice(ctx, q.Region, q.InstanceType, q.Tenancy, q.OperatingSystem, q.SpotWindow)
+	case "Reserved":
+		quote, err = c.getReservedPrice(ctx, q.Region, q.InstanceType, q.Tenancy, q.OperatingSystem, q.TermOptions)
+	case "SavingsPlan":
+		quote, err = c.getSavingsPlanPrice(ctx, q.Region, q.InstanceType)
+	default:
+		quote, err = c.getOnDemandPrice(ctx, q.Region, q.InstanceType, q.Tenancy, q.OperatingSystem)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(quote); err != nil {
+		return nil, err
+	}
+	c.cache.Set(ctx, key.String(), buf.Bytes(), QUOTE_CACHE_TTL)
+
+	return quote, nil
+}
+
+// Prices fans out concurrent price lookups across every
+// region/instance type combination in q, bounded by
+// MAX_CONCURRENT_REQUESTS in flight at once. This lets callers ask for
+// something like "m5.large across every region" in one call instead of
+// one sequential Price call per region.
+func (c *Client) Prices(ctx context.Context, q BatchQuery) (map[string]map[string]*PriceQuote, error) {
+
+	jobs := make(chan priceJob)
+	results := make(chan priceResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < MAX_CONCURRENT_REQUESTS; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				quote, err := c.Price(ctx, Query{
+					Region:          job.region,
+					InstanceType:    job.instance_type,
+					Tenancy:         q.Tenancy,
+					OperatingSystem: q.OperatingSystem,
+					Term:            q.Term,
+					TermOptions:     q.TermOptions,
+					SpotWindow:      q.SpotWindow,
+				})
+				results <- priceResult{region: job.region, instance_type: job.instance_type, quote: quote, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, region := range q.Regions {
+			for _, instance_type := range q.InstanceTypes {
+				jobs <- priceJob{region: region, instance_type: instance_type}
+			}
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	prices := make(map[string]map[string]*PriceQuote)
+	var firstErr error
+	for result := range results {
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s %s: %s", result.region, result.instance_type, result.err)
+			}
+			continue
+		}
+
+		if prices[result.region] == nil {
+			prices[result.region] = make(map[string]*PriceQuote)
+		}
+		prices[result.region][result.instance_type] = result.quote
+	}
+
+	return prices, firstErr
+}
+
+// Products returns every product matching filter. Unlike Price, it
+// does not require the match to be unique.
+func (c *Client) Products(ctx context.Context, filter Filter) ([]Product, error) {
+
+	location, ok := regionLocations[filter.Region]
+	if !ok {
+		return nil, fmt.Errorf("unknown region: %s", filter.Region)
+	}
+
+	apiFilters := []*pricing.Filter{
+		{Type: aws.String("TERM_MATCH"), Field: aws.String("location"), Value: aws.String(location)},
+	}
+	if filter.InstanceType != "" {
+		apiFilters = append(apiFilters, &pricing.Filter{Type: aws.String("TERM_MATCH"), Field: aws.String("instanceType"), Value: aws.String(filter.InstanceType)})
+	}
+	if filter.Tenancy != "" {
+		apiFilters = append(apiFilters, &pricing.Filter{Type: aws.String("TERM_MATCH"), Field: aws.String("tenancy"), Value: aws.String(filter.Tenancy)})
+	}
+	if filter.OperatingSystem != "" {
+		apiFilters = append(apiFilters, &pricing.Filter{Type: aws.String("TERM_MATCH"), Field: aws.String("operatingSystem"), Value: aws.String(filter.OperatingSystem)})
+	}
+
+	var products []Product
+	var decodeErr error
+	err := c.pricingSvc.GetProductsPagesWithContext(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonEC2"),
+		Filters:     apiFilters,
+	}, func(page *pricing.GetProductsOutput, lastPage bool) bool {
+		for _, raw := range page.PriceList {
+			entry, err := decodePriceListEntry(raw)
+			if err != nil {
+				decodeErr = err
+				return false
+			}
+			products = append(products, entry.Product)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	return products, nil
+}
+
+// getOnDemandPrice looks up the standard OnDemand price.
+func (c *Client) getOnDemandPrice(ctx context.Context, region string, instance_type string, tenancy string, operating_system string) (*PriceQuote, error) {
+
+	sku, err := c.sku(ctx, region, instance_type, tenancy, operating_system)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := c.priceForSKU(ctx, sku, "OnDemand")
+	if err != nil {
+		return nil, err
+	}
+
+	hourly, err := strconv.ParseFloat(price.Value, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriceQuote{
+		Term:            "OnDemand",
+		HourlyCost:      hourly,
+		EffectiveHourly: hourly,
+	}, nil
+}
+
+// getReservedPrice looks up the Reserved Instance offering matching
+// opts and amortizes its upfront cost over the lease term.
+func (c *Client) getReservedPrice(ctx context.Context, region string, instance_type string, tenancy string, operating_system string, opts TermOptions) (*PriceQuote, error) {
+
+	sku, err := c.sku(ctx, region, instance_type, tenancy, operating_system)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.pricingSvc.GetProductsWithContext(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonEC2"),
+		Filters: []*pricing.Filter{
+			{Type: aws.String("TERM_MATCH"), Field: aws.String("sku"), Value: aws.String(sku)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.PriceList) == 0 {
+		return nil, errors.New("could not find price")
+	}
+
+	entry, err := decodePriceListEntry(out.PriceList[0])
+	if err != nil {
+		return nil, err
+	}
+
+	term_hours, ok := reservedTermHours[opts.LeaseContractLength]
+	if !ok {
+		return nil, fmt.Errorf("unknown lease contract length: %s", opts.LeaseContractLength)
+	}
+
+	hourly, upfront, err := reservedPriceFromEntry(entry, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriceQuote{
+		Term:            "Reserved",
+		TermOptions:     &opts,
+		HourlyCost:      hourly,
+		UpfrontCost:     upfront,
+		EffectiveHourly: hourly + (upfront / term_hours),
+	}, nil
+}
+
+// reservedPriceFromEntry finds the Reserved Instance term in entry
+// matching opts and returns its hourly and upfront (if any) cost. It
+// returns an error if no term matches opts.
+func reservedPriceFromEntry(entry *priceListEntry, opts TermOptions) (hourly float64, upfront float64, err error) {
+	found := false
+	for _, skuTerms := range entry.Terms["Reserved"] {
+		for _, t := range skuTerms {
+			if t.TermAttributes["LeaseContractLength"] != opts.LeaseContractLength ||
+				t.TermAttributes["PurchaseOption"] != opts.PurchaseOption ||
+				t.TermAttributes["OfferingClass"] != opts.OfferingClass {
+				continue
+			}
+
+			for _, price_dimension := range t.PriceDimensions {
+				value, err := strconv.ParseFloat(price_dimension.PricePerUnit["USD"], 64)
+				if err != nil {
+					return 0, 0, err
+				}
+
+				if price_dimension.Unit == "Quantity" {
+					upfront = value
+				} else {
+					hourly = value
+				}
+			}
+			found = true
+		}
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("no matching Reserved Instance term found for %+v", opts)
+	}
+
+	return hourly, upfront, nil
+}
+
+// getSpotPrice averages ec2.DescribeSpotPriceHistory over window,
+// separately for each Availability Zone in region, and returns the
+// cheapest AZ's average as HourlyCost/EffectiveHourly alongside every
+// AZ's average in SpotPrices so callers can compare AZs themselves.
+// Spot only supports Shared (default) tenancy.
+func (c *Client) getSpotPrice(ctx context.Context, region string, instance_type string, tenancy string, operating_system string, window time.Duration) (*PriceQuote, error) {
+
+	if tenancy != "Shared" {
+		return nil, fmt.Errorf("spot pricing does not support tenancy %q", tenancy)
+	}
+
+	product_description, ok := spotProductDescriptions[operating_system]
+	if !ok {
+		return nil, fmt.Errorf("spot pricing does not support operating system %q", operating_system)
+	}
+
+	svc := ec2.New(c.sess, aws.NewConfig().WithRegion(region))
+
+	end := time.Now()
+	start := end.Add(-window)
+
+	var history []*ec2.SpotPrice
+	err := svc.DescribeSpotPriceHistoryPagesWithContext(ctx, &ec2.DescribeSpotPriceHistoryInput{
+		InstanceTypes:       []*string{aws.String(instance_type)},
+		ProductDescriptions: []*string{aws.String(product_description)},
+		StartTime:           aws.Time(start),
+		EndTime:             aws.Time(end),
+	}, func(page *ec2.DescribeSpotPriceHistoryOutput, lastPage bool) bool {
+		history = append(history, page.SpotPriceHistory...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	spotPrices, cheapestAZ := averageSpotPricesByAZ(history)
+	if len(spotPrices) == 0 {
+		return nil, fmt.Errorf("no spot price history found for %s in %s", instance_type, region)
+	}
+
+	hourly := spotPrices[cheapestAZ]
+
+	return &PriceQuote{
+		Term:            "Spot",
+		HourlyCost:      hourly,
+		EffectiveHourly: hourly,
+		SpotPrices:      spotPrices,
+	}, nil
+}
+
+// averageSpotPricesByAZ groups history by Availability Zone and
+// averages the Spot price within each, returning the per-AZ averages
+// and the cheapest AZ. Entries with an unparseable SpotPrice are
+// skipped.
+func averageSpotPricesByAZ(history []*ec2.SpotPrice) (spotPrices map[string]float64, cheapestAZ string) {
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	for _, entry := range history {
+		value, err := strconv.ParseFloat(aws.StringValue(entry.SpotPrice), 64)
+		if err != nil {
+			continue
+		}
+		az := aws.StringValue(entry.AvailabilityZone)
+		sums[az] += value
+		counts[az]++
+	}
+
+	spotPrices = make(map[string]float64, len(counts))
+	for az, count := range counts {
+		spotPrices[az] = sums[az] / float64(count)
+		if cheapestAZ == "" || spotPrices[az] < spotPrices[cheapestAZ] {
+			cheapestAZ = az
+		}
+	}
+
+	return spotPrices, cheapestAZ
+}
+
+// getSavingsPlanPrice looks up the effective hourly rate for
+// instance_type in region across Compute and EC2Instance Savings
+// Plans.
+func (c *Client) getSavingsPlanPrice(ctx context.Context, region string, instance_type string) (*PriceQuote, error) {
+
+	location, ok := regionLocations[region]
+	if !ok {
+		return nil, fmt.Errorf("unknown region: %s", region)
+	}
+
+	svc := savingsplans.New(c.sess, aws.NewConfig().WithRegion(c.pricingRegion))
+
+	out, err := svc.DescribeSavingsPlansOfferingRatesWithContext(ctx, &savingsplans.DescribeSavingsPlansOfferingRatesInput{
+		SavingsPlanTypes: []*string{aws.String("Compute"), aws.String("EC2Instance")},
+		Filters: []*savingsplans.SavingsPlanOfferingRateFilterElement{
+			{Name: aws.String("region"), Values: []*string{aws.String(location)}},
+			{Name: aws.String("instanceType"), Values: []*string{aws.String(instance_type)}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.SearchResults) == 0 {
+		return nil, fmt.Errorf("no Savings Plan rate found for %s in %s", instance_type, region)
+	}
+
+	hourly, err := strconv.ParseFloat(aws.StringValue(out.SearchResults[0].Rate), 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriceQuote{
+		Term:            "SavingsPlan",
+		HourlyCost:      hourly,
+		EffectiveHourly: hourly,
+	}, nil
+}
+
+// sku looks up the single product SKU matching instance_type, tenancy
+// and operating_system in region, using server-side filters so that
+// only matching products come back across the wire; it returns an
+// error if none or more than one product matches. SKUs are cached for
+// SKU_CACHE_TTL, refreshed in the background once near expiry, since
+// they change far less often than prices.
+func (c *Client) sku(ctx context.Context, region string, instance_type string, tenancy string, operating_system string) (string, error) {
+
+	key := skuKey(region, instance_type, tenancy, operating_system)
+
+	if body, remaining, ok := c.cache.Get(ctx, key); ok {
+		if nearExpiry(remaining, SKU_CACHE_TTL) {
+			go func() {
+				bgCtx := context.Background()
+				if _, err := c.fetchSKU(bgCtx, region, instance_type, tenancy, operating_system); err != nil {
+					c.logger.Printf("Background SKU refresh failed for %s %s: %s", region, instance_type, err)
+				}
+			}()
+		}
+		return string(body), nil
+	}
+
+	return c.fetchSKU(ctx, region, instance_type, tenancy, operating_system)
+}
+
+// fetchSKU fetches a fresh SKU from the Pricing API and caches it
+// under skuKey(region, instance_type, tenancy, operating_system) for
+// SKU_CACHE_TTL.
+func (c *Client) fetchSKU(ctx context.Context, region string, instance_type string, tenancy string, operating_system string) (string, error) {
+
+	location, ok := regionLocations[region]
+	if !ok {
+		return "", fmt.Errorf("unknown region: %s", region)
+	}
+
+	out, err := c.pricingSvc.GetProductsWithContext(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonEC2"),
+		Filters: []*pricing.Filter{
+			{Type: aws.String("TERM_MATCH"), Field: aws.String("instanceType"), Value: aws.String(instance_type)},
+			{Type: aws.String("TERM_MATCH"), Field: aws.String("location"), Value: aws.String(location)},
+			{Type: aws.String("TERM_MATCH"), Field: aws.String("tenancy"), Value: aws.String(tenancy)},
+			{Type: aws.String("TERM_MATCH"), Field: aws.String("operatingSystem"), Value: aws.String(operating_system)},
+			{Type: aws.String("TERM_MATCH"), Field: aws.String("capacitystatus"), Value: aws.String("Used")},
+			{Type: aws.String("TERM_MATCH"), Field: aws.String("preInstalledSw"), Value: aws.String("NA")},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(out.PriceList) == 0 {
+		return "", errors.New("no matching SKU found")
+	}
+	if len(out.PriceList) > 1 {
+		return "", errors.New("more than one SKU found")
+	}
+
+	entry, err := decodePriceListEntry(out.PriceList[0])
+	if err != nil {
+		return "", err
+	}
+
+	c.cache.Set(ctx, skuKey(region, instance_type, tenancy, operating_system), []byte(entry.Product.SKU), SKU_CACHE_TTL)
+
+	return entry.Product.SKU, nil
+}
+
+// priceForSKU fetches the price for sku under term ("OnDemand" or
+// "Reserved").
+func (c *Client) priceForSKU(ctx context.Context, sku string, term string) (*Price, error) {
+
+	out, err := c.pricingSvc.GetProductsWithContext(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonEC2"),
+		Filters: []*pricing.Filter{
+			{Type: aws.String("TERM_MATCH"), Field: aws.String("sku"), Value: aws.String(sku)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.PriceList) == 0 {
+		return nil, errors.New("could not find price")
+	}
+
+	entry, err := decodePriceListEntry(out.PriceList[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, skuTerms := range entry.Terms[term] {
+		for _, t := range skuTerms {
+			for _, price_dimension := range t.PriceDimensions {
+				for currency, value := range price_dimension.PricePerUnit {
+					return &Price{
+						Currency: currency,
+						Value:    value,
+					}, nil
+				}
+			}
+		}
+	}
+
+	return nil, errors.New("could not find price")
+}
+
+// decodePriceListEntry re-marshals one of the aws.JSONValue entries in
+// GetProductsOutput.PriceList into a priceListEntry.
+func decodePriceListEntry(v aws.JSONValue) (*priceListEntry, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &priceListEntry{}
+	if err := json.Unmarshal(raw, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func GetMD5Hash(text string) string {
+	hasher := md5.New()
+	hasher.Write([]byte(text))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
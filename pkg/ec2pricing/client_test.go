@@ -0,0 +1,164 @@
+package ec2pricing
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestAverageSpotPricesByAZ(t *testing.T) {
+	history := []*ec2.SpotPrice{
+		{AvailabilityZone: aws.String("us-west-2a"), SpotPrice: aws.String("0.10")},
+		{AvailabilityZone: aws.String("us-west-2a"), SpotPrice: aws.String("0.20")},
+		{AvailabilityZone: aws.String("us-west-2b"), SpotPrice: aws.String("0.05")},
+		{AvailabilityZone: aws.String("us-west-2b"), SpotPrice: aws.String("0.05")},
+		{AvailabilityZone: aws.String("us-west-2b"), SpotPrice: aws.String("0.05")},
+		{AvailabilityZone: aws.String("us-west-2c"), SpotPrice: aws.String("not-a-number")},
+	}
+
+	spotPrices, cheapestAZ := averageSpotPricesByAZ(history)
+
+	want := map[string]float64{
+		"us-west-2a": 0.15,
+		"us-west-2b": 0.05,
+	}
+	if len(spotPrices) != len(want) {
+		t.Fatalf("spotPrices = %+v, want %+v", spotPrices, want)
+	}
+	for az, price := range want {
+		if diff := spotPrices[az] - price; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("spotPrices[%q] = %v, want %v", az, spotPrices[az], price)
+		}
+	}
+
+	if cheapestAZ != "us-west-2b" {
+		t.Errorf("cheapestAZ = %q, want %q", cheapestAZ, "us-west-2b")
+	}
+}
+
+func TestAverageSpotPricesByAZEmpty(t *testing.T) {
+	spotPrices, cheapestAZ := averageSpotPricesByAZ(nil)
+	if len(spotPrices) != 0 {
+		t.Errorf("spotPrices = %+v, want empty", spotPrices)
+	}
+	if cheapestAZ != "" {
+		t.Errorf("cheapestAZ = %q, want empty", cheapestAZ)
+	}
+}
+
+func TestReservedPriceFromEntry(t *testing.T) {
+	entry := &priceListEntry{
+		Terms: map[string]map[string]map[string]Term{
+			"Reserved": {
+				"SKU.OFFER1": {
+					"SKU.OFFER1.RATECODE1": {
+						TermAttributes: map[string]string{
+							"LeaseContractLength": "1yr",
+							"PurchaseOption":      "No Upfront",
+							"OfferingClass":       "standard",
+						},
+						PriceDimensions: map[string]PriceDimension{
+							"SKU.OFFER1.RATECODE1.HOURLY": {
+								Unit:         "Hrs",
+								PricePerUnit: map[string]string{"USD": "0.100000000"},
+							},
+						},
+					},
+				},
+				"SKU.OFFER2": {
+					"SKU.OFFER2.RATECODE1": {
+						TermAttributes: map[string]string{
+							"LeaseContractLength": "1yr",
+							"PurchaseOption":      "All Upfront",
+							"OfferingClass":       "standard",
+						},
+						PriceDimensions: map[string]PriceDimension{
+							"SKU.OFFER2.RATECODE1.UPFRONT": {
+								Unit:         "Quantity",
+								PricePerUnit: map[string]string{"USD": "876.000000000"},
+							},
+							"SKU.OFFER2.RATECODE1.HOURLY": {
+								Unit:         "Hrs",
+								PricePerUnit: map[string]string{"USD": "0.000000000"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name        string
+		opts        TermOptions
+		wantHourly  float64
+		wantUpfront float64
+		wantErr     bool
+	}{
+		{
+			name: "no upfront",
+			opts: TermOptions{
+				LeaseContractLength: "1yr",
+				PurchaseOption:      "No Upfront",
+				OfferingClass:       "standard",
+			},
+			wantHourly:  0.1,
+			wantUpfront: 0,
+		},
+		{
+			name: "all upfront",
+			opts: TermOptions{
+				LeaseContractLength: "1yr",
+				PurchaseOption:      "All Upfront",
+				OfferingClass:       "standard",
+			},
+			wantHourly:  0,
+			wantUpfront: 876,
+		},
+		{
+			name: "no matching term",
+			opts: TermOptions{
+				LeaseContractLength: "3yr",
+				PurchaseOption:      "No Upfront",
+				OfferingClass:       "standard",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			hourly, upfront, err := reservedPriceFromEntry(entry, tc.opts)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("reservedPriceFromEntry(%+v) = nil error, want error", tc.opts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reservedPriceFromEntry(%+v) returned error: %s", tc.opts, err)
+			}
+			if hourly != tc.wantHourly {
+				t.Errorf("hourly = %v, want %v", hourly, tc.wantHourly)
+			}
+			if upfront != tc.wantUpfront {
+				t.Errorf("upfront = %v, want %v", upfront, tc.wantUpfront)
+			}
+		})
+	}
+}
+
+func TestRegionLocations(t *testing.T) {
+	location, ok := regionLocations["us-west-2"]
+	if !ok {
+		t.Fatal("us-west-2 missing from regionLocations")
+	}
+	if location != "US West (Oregon)" {
+		t.Errorf("regionLocations[%q] = %q, want %q", "us-west-2", location, "US West (Oregon)")
+	}
+
+	if _, ok := regionLocations["not-a-real-region"]; ok {
+		t.Error("regionLocations contained an entry for a made-up region")
+	}
+}